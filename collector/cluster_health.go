@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
@@ -21,6 +23,49 @@ var (
 	defaultClusterHealthLabels = []string{"cluster"}
 )
 
+type clusterHealthResponse struct {
+	ClusterName                 string                                `json:"cluster_name"`
+	Status                      string                                `json:"status"`
+	TimedOut                    bool                                  `json:"timed_out"`
+	NumberOfNodes               int                                   `json:"number_of_nodes"`
+	NumberOfDataNodes           int                                   `json:"number_of_data_nodes"`
+	ActivePrimaryShards         int                                   `json:"active_primary_shards"`
+	ActiveShards                int                                   `json:"active_shards"`
+	RelocatingShards            int                                   `json:"relocating_shards"`
+	InitializingShards          int                                   `json:"initializing_shards"`
+	UnassignedShards            int                                   `json:"unassigned_shards"`
+	DelayedUnassignedShards     int                                   `json:"delayed_unassigned_shards"`
+	NumberOfPendingTasks        int                                   `json:"number_of_pending_tasks"`
+	NumberOfInFlightFetch       int                                   `json:"number_of_in_flight_fetch"`
+	TaskMaxWaitingInQueueMillis int                                   `json:"task_max_waiting_in_queue_millis"`
+	ActiveShardsPercentAsNumber float64                               `json:"active_shards_percent_as_number"`
+	Indices                     map[string]clusterHealthIndexResponse `json:"indices,omitempty"`
+}
+
+// clusterHealthIndexResponse is the per-index entry present in the
+// `/_cluster/health` response when queried with `?level=indices` (or
+// `?level=shards`).
+type clusterHealthIndexResponse struct {
+	Status              string                                  `json:"status"`
+	NumberOfShards      int                                     `json:"number_of_shards"`
+	NumberOfReplicas    int                                     `json:"number_of_replicas"`
+	ActivePrimaryShards int                                     `json:"active_primary_shards"`
+	ActiveShards        int                                     `json:"active_shards"`
+	RelocatingShards    int                                     `json:"relocating_shards"`
+	InitializingShards  int                                     `json:"initializing_shards"`
+	UnassignedShards    int                                     `json:"unassigned_shards"`
+	Shards              map[string][]clusterHealthShardResponse `json:"shards,omitempty"`
+}
+
+// clusterHealthShardResponse is one shard copy in the per-shard breakdown
+// present in the `/_cluster/health` response when queried with
+// `?level=shards`; each shard number maps to one entry per copy (the
+// primary plus each replica).
+type clusterHealthShardResponse struct {
+	State   string `json:"state"`
+	Primary bool   `json:"primary"`
+}
+
 type clusterHealthMetric struct {
 	Type  prometheus.ValueType
 	Desc  *prometheus.Desc
@@ -34,25 +79,63 @@ type clusterHealthStatusMetric struct {
 	Labels func(clusterName, color string) []string
 }
 
+// ClusterHealthOption configures a ClusterHealth collector.
+type ClusterHealthOption func(*ClusterHealth)
+
+// WithMasterOnly restricts cluster-wide health metrics to scrapes against
+// the elected master node. The collector still reports scrape-meta gauges
+// and cluster_health_is_master on every scrape, but skips the cluster
+// health metrics themselves when the scraped node isn't master, which
+// avoids duplicate series when an exporter is deployed against every node
+// in the cluster.
+func WithMasterOnly(enabled bool) ClusterHealthOption {
+	return func(c *ClusterHealth) {
+		c.masterOnly = enabled
+	}
+}
+
+// WithCacheTTL enables an in-memory cache of the `/_cluster/health`
+// response, shared across concurrent scrapes, so that multiple Prometheus
+// servers (or scrape jobs) polling the same exporter don't each trigger
+// their own request against the cluster. Disabled (ttl <= 0) by default.
+func WithCacheTTL(ttl time.Duration) ClusterHealthOption {
+	return func(c *ClusterHealth) {
+		c.cacheTTL = ttl
+	}
+}
+
 type ClusterHealth struct {
 	logger log.Logger
 	client *http.Client
 	url    *url.URL
 
+	masterOnly bool
+
+	cacheTTL       time.Duration
+	cacheGroup     singleflight.Group
+	cacheMu        sync.Mutex
+	cached         clusterHealthResponse
+	cachedErr      error
+	cachedAt       time.Time
+	cachedDuration time.Duration
+
 	metrics      []*clusterHealthMetric
 	statusMetric *clusterHealthStatusMetric
+	isMasterDesc *prometheus.Desc
 
-	totalScrapesMetric              prometheus.Counter
-	totalScrapeErrorsMetric         prometheus.Counter
-	lastScrapeErrorMetric           prometheus.Gauge
-	lastScrapeTimestampMetric       prometheus.Gauge
-	lastScrapeDurationSecondsMetric prometheus.Gauge
+	totalScrapesMetric        *prometheus.CounterVec
+	lastScrapeErrorMetric     prometheus.Gauge
+	lastScrapeTimestampMetric prometheus.Gauge
+	scrapeDurationMetric      *prometheus.HistogramVec
+	scrapeDurationSummary     *prometheus.SummaryVec
+	cacheHitsMetric           prometheus.Counter
+	cacheMissesMetric         prometheus.Counter
 }
 
-func NewClusterHealth(logger log.Logger, client *http.Client, url *url.URL) *ClusterHealth {
+func NewClusterHealth(logger log.Logger, client *http.Client, url *url.URL, opts ...ClusterHealthOption) *ClusterHealth {
 	subsystem := "cluster_health"
 
-	return &ClusterHealth{
+	c := &ClusterHealth{
 		logger: logger,
 		client: client,
 		url:    url,
@@ -80,6 +163,17 @@ func NewClusterHealth(logger log.Logger, client *http.Client, url *url.URL) *Clu
 					return float64(clusterHealth.ActiveShards)
 				},
 			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "active_shards_percent"),
+					"The ratio of active shards in the cluster expressed as a percentage.",
+					defaultClusterHealthLabels, nil,
+				),
+				Value: func(clusterHealth clusterHealthResponse) float64 {
+					return clusterHealth.ActiveShardsPercentAsNumber
+				},
+			},
 			{
 				Type: prometheus.GaugeValue,
 				Desc: prometheus.NewDesc(
@@ -157,6 +251,17 @@ func NewClusterHealth(logger log.Logger, client *http.Client, url *url.URL) *Clu
 					return float64(clusterHealth.RelocatingShards)
 				},
 			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "task_max_waiting_in_queue_seconds"),
+					"Tasks max time waiting in queue, in seconds.",
+					defaultClusterHealthLabels, nil,
+				),
+				Value: func(clusterHealth clusterHealthResponse) float64 {
+					return float64(clusterHealth.TaskMaxWaitingInQueueMillis) / 1000
+				},
+			},
 			{
 				Type: prometheus.GaugeValue,
 				Desc: prometheus.NewDesc(
@@ -197,27 +302,22 @@ func NewClusterHealth(logger log.Logger, client *http.Client, url *url.URL) *Clu
 				return 0
 			},
 		},
-		totalScrapesMetric: prometheus.NewCounter(
-			prometheus.CounterOpts{
-				Namespace: namespace,
-				Subsystem: "cluster_health",
-				Name:      "scrapes_total",
-				Help:      "Total number of times ElasticSearch cluster health was scraped for metrics.",
-				ConstLabels: prometheus.Labels{
-					"url": url.String(),
-				},
-			},
+		isMasterDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "is_master"),
+			"Whether the scraped node is the elected master node (1) or not (0). Only populated when master-only mode is enabled.",
+			[]string{"cluster", "node"}, nil,
 		),
-		totalScrapeErrorsMetric: prometheus.NewCounter(
+		totalScrapesMetric: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: namespace,
 				Subsystem: "cluster_health",
-				Name:      "scrape_errors_total",
-				Help:      "Total number of times an error occured scraping ElasticSearch cluster health.",
+				Name:      "scrapes_total",
+				Help:      "Total number of times ElasticSearch cluster health was scraped for metrics, by outcome.",
 				ConstLabels: prometheus.Labels{
 					"url": url.String(),
 				},
 			},
+			[]string{"outcome"},
 		),
 		lastScrapeErrorMetric: prometheus.NewGauge(
 			prometheus.GaugeOpts{
@@ -241,18 +341,61 @@ func NewClusterHealth(logger log.Logger, client *http.Client, url *url.URL) *Clu
 				},
 			},
 		),
-		lastScrapeDurationSecondsMetric: prometheus.NewGauge(
-			prometheus.GaugeOpts{
+		scrapeDurationMetric: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "cluster_health",
+				Name:      "scrape_duration_seconds",
+				Help:      "Duration of a scrape of ElasticSearch cluster health, by outcome.",
+				Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+			[]string{"outcome"},
+		),
+		scrapeDurationSummary: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Namespace:  namespace,
+				Subsystem:  "cluster_health",
+				Name:       "scrape_duration_seconds_summary",
+				Help:       "Duration of a scrape of ElasticSearch cluster health, by outcome, as quantiles.",
+				Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+			[]string{"outcome"},
+		),
+		cacheHitsMetric: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "cluster_health",
+				Name:      "cache_hits_total",
+				Help:      "Total number of times a cluster health scrape was served from cache.",
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+		),
+		cacheMissesMetric: prometheus.NewCounter(
+			prometheus.CounterOpts{
 				Namespace: namespace,
 				Subsystem: "cluster_health",
-				Name:      "last_scrape_duration_seconds",
-				Help:      "Duration of the last scrape from ElasticSearch cluster health.",
+				Name:      "cache_misses_total",
+				Help:      "Total number of times a cluster health scrape required an upstream fetch.",
 				ConstLabels: prometheus.Labels{
 					"url": url.String(),
 				},
 			},
 		),
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 func (c *ClusterHealth) Describe(ch chan<- *prometheus.Desc) {
@@ -260,6 +403,15 @@ func (c *ClusterHealth) Describe(ch chan<- *prometheus.Desc) {
 		ch <- metric.Desc
 	}
 	ch <- c.statusMetric.Desc
+	ch <- c.isMasterDesc
+
+	c.totalScrapesMetric.Describe(ch)
+	ch <- c.lastScrapeErrorMetric.Desc()
+	ch <- c.lastScrapeTimestampMetric.Desc()
+	c.scrapeDurationMetric.Describe(ch)
+	c.scrapeDurationSummary.Describe(ch)
+	ch <- c.cacheHitsMetric.Desc()
+	ch <- c.cacheMissesMetric.Desc()
 }
 
 func (c *ClusterHealth) fetchAndDecodeClusterHealth() (clusterHealthResponse, error) {
@@ -284,48 +436,224 @@ func (c *ClusterHealth) fetchAndDecodeClusterHealth() (clusterHealthResponse, er
 	return chr, nil
 }
 
+// fetchAndDecodeClusterHealthCached serves `/_cluster/health` out of an
+// in-memory cache when WithCacheTTL is enabled, deduplicating concurrent
+// refreshes with a singleflight.Group so a scrape-storm against an expired
+// cache entry results in a single upstream fetch. It returns the response
+// alongside whether it came from an upstream fetch, made by this call or
+// shared with a concurrent one (as opposed to serving a cached entry),
+// plus the time and duration of that upstream
+// fetch, so scrape-meta gauges can reflect the real fetch rather than the
+// time of the cached serve.
+func (c *ClusterHealth) fetchAndDecodeClusterHealthCached() (chr clusterHealthResponse, upstream bool, fetchedAt time.Time, duration time.Duration, err error) {
+	if c.cacheTTL <= 0 {
+		begun := time.Now()
+		chr, err = c.fetchAndDecodeClusterHealth()
+		return chr, true, begun, time.Since(begun), err
+	}
+
+	c.cacheMu.Lock()
+	fresh := !c.cachedAt.IsZero() && time.Since(c.cachedAt) < c.cacheTTL
+	if fresh {
+		chr = c.cached
+		err = c.cachedErr
+		fetchedAt = c.cachedAt
+		duration = c.cachedDuration
+	}
+	c.cacheMu.Unlock()
+	if fresh {
+		c.cacheHitsMetric.Inc()
+		return chr, false, fetchedAt, duration, err
+	}
+
+	v, err, _ := c.cacheGroup.Do(c.url.String(), func() (interface{}, error) {
+		begun := time.Now()
+		chr, ferr := c.fetchAndDecodeClusterHealth()
+
+		c.cacheMu.Lock()
+		c.cached = chr
+		c.cachedErr = ferr
+		c.cachedAt = begun
+		c.cachedDuration = time.Since(begun)
+		c.cacheMu.Unlock()
+
+		return chr, ferr
+	})
+	c.cacheMissesMetric.Inc()
+
+	c.cacheMu.Lock()
+	fetchedAt = c.cachedAt
+	duration = c.cachedDuration
+	c.cacheMu.Unlock()
+
+	if chr, ok := v.(clusterHealthResponse); ok {
+		return chr, true, fetchedAt, duration, err
+	}
+	return clusterHealthResponse{}, true, fetchedAt, duration, err
+}
+
+// localNode identifies the node being scraped, as reported by itself.
+type localNode struct {
+	ID   string
+	Name string
+}
+
+func (c *ClusterHealth) fetchLocalNode() (localNode, error) {
+	var node localNode
+
+	u := *c.url
+	u.Path = "/_nodes/_local"
+	res, err := c.client.Get(u.String())
+	if err != nil {
+		return node, fmt.Errorf("failed to get local node info from %s: %s", u.String(), err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return node, fmt.Errorf("HTTP Request failed with code %d", res.StatusCode)
+	}
+
+	var nlr struct {
+		Nodes map[string]struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&nlr); err != nil {
+		return node, err
+	}
+
+	for id, n := range nlr.Nodes {
+		node.ID = id
+		node.Name = n.Name
+		break
+	}
+
+	return node, nil
+}
+
+func (c *ClusterHealth) fetchMasterNodeID() (string, error) {
+	u := *c.url
+	u.Path = "/_cat/master"
+	q := u.Query()
+	q.Set("h", "id")
+	q.Set("format", "json")
+	u.RawQuery = q.Encode()
+
+	res, err := c.client.Get(u.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to get master node from %s: %s", u.String(), err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP Request failed with code %d", res.StatusCode)
+	}
+
+	var cmr []struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&cmr); err != nil {
+		return "", err
+	}
+	if len(cmr) == 0 {
+		return "", fmt.Errorf("no master node reported by %s", u.String())
+	}
+
+	return cmr[0].ID, nil
+}
+
+// isMaster reports whether the scraped node is the elected master, along
+// with the scraped node's name for labeling purposes.
+func (c *ClusterHealth) isMaster() (bool, string, error) {
+	node, err := c.fetchLocalNode()
+	if err != nil {
+		return false, "", err
+	}
+
+	masterID, err := c.fetchMasterNodeID()
+	if err != nil {
+		return false, node.Name, err
+	}
+
+	return node.ID == masterID, node.Name, nil
+}
+
 func (c *ClusterHealth) Collect(ch chan<- prometheus.Metric) {
-	begun := time.Now()
 	scrapeError := 0
-	c.totalScrapesMetric.Inc()
 
-	clusterHealthResponse, err := c.fetchAndDecodeClusterHealth()
+	clusterHealthResponse, upstreamFetch, fetchedAt, fetchDuration, err := c.fetchAndDecodeClusterHealthCached()
 	if err != nil {
 		level.Warn(c.logger).Log(
 			"msg", "failed to fetch and decode cluster health",
 			"err", err,
 		)
 		scrapeError = 1
-		c.totalScrapeErrorsMetric.Inc()
 	}
 
-	for _, metric := range c.metrics {
+	isMaster := true
+	nodeName := ""
+	if c.masterOnly {
+		isMaster, nodeName, err = c.isMaster()
+		if err != nil {
+			level.Warn(c.logger).Log(
+				"msg", "failed to determine master node",
+				"err", err,
+			)
+			scrapeError = 1
+		}
+
+		isMasterValue := 0.0
+		if isMaster {
+			isMasterValue = 1.0
+		}
 		ch <- prometheus.MustNewConstMetric(
-			metric.Desc,
-			metric.Type,
-			metric.Value(clusterHealthResponse),
-			clusterHealthResponse.ClusterName,
+			c.isMasterDesc,
+			prometheus.GaugeValue,
+			isMasterValue,
+			clusterHealthResponse.ClusterName, nodeName,
 		)
 	}
 
-	for _, color := range colors {
-		ch <- prometheus.MustNewConstMetric(
-			c.statusMetric.Desc,
-			c.statusMetric.Type,
-			c.statusMetric.Value(clusterHealthResponse, color),
-			clusterHealthResponse.ClusterName, color,
-		)
+	if !c.masterOnly || isMaster {
+		for _, metric := range c.metrics {
+			ch <- prometheus.MustNewConstMetric(
+				metric.Desc,
+				metric.Type,
+				metric.Value(clusterHealthResponse),
+				clusterHealthResponse.ClusterName,
+			)
+		}
+
+		for _, color := range colors {
+			ch <- prometheus.MustNewConstMetric(
+				c.statusMetric.Desc,
+				c.statusMetric.Type,
+				c.statusMetric.Value(clusterHealthResponse, color),
+				clusterHealthResponse.ClusterName, color,
+			)
+		}
 	}
 
+	outcome := "success"
+	if scrapeError == 1 {
+		outcome = "error"
+	}
+	c.totalScrapesMetric.WithLabelValues(outcome).Inc()
 	c.totalScrapesMetric.Collect(ch)
-	c.totalScrapeErrorsMetric.Collect(ch)
 
 	c.lastScrapeErrorMetric.Set(float64(scrapeError))
 	c.lastScrapeErrorMetric.Collect(ch)
 
-	c.lastScrapeTimestampMetric.Set(float64(time.Now().Unix()))
+	c.lastScrapeTimestampMetric.Set(float64(fetchedAt.Unix()))
 	c.lastScrapeTimestampMetric.Collect(ch)
 
-	c.lastScrapeDurationSecondsMetric.Set(time.Since(begun).Seconds())
-	c.lastScrapeDurationSecondsMetric.Collect(ch)
+	if upstreamFetch {
+		c.scrapeDurationMetric.WithLabelValues(outcome).Observe(fetchDuration.Seconds())
+		c.scrapeDurationSummary.WithLabelValues(outcome).Observe(fetchDuration.Seconds())
+	}
+	c.scrapeDurationMetric.Collect(ch)
+	c.scrapeDurationSummary.Collect(ch)
+
+	c.cacheHitsMetric.Collect(ch)
+	c.cacheMissesMetric.Collect(ch)
 }
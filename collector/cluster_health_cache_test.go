@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestClusterHealthCacheTTL(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.ServeFile(w, r, "fixtures/cluster_health.json")
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %s", err)
+	}
+
+	c := NewClusterHealth(log.NewNopLogger(), http.DefaultClient, u, WithCacheTTL(time.Minute))
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+
+	for i := 0; i < 2; i++ {
+		if _, err := reg.Gather(); err != nil {
+			t.Fatalf("unexpected error gathering metrics: %s", err)
+		}
+	}
+
+	// The third scrape doubles as the assertion below, so the expected
+	// cache counter values reflect this call too.
+	want := `
+		# HELP elasticsearch_cluster_health_cache_hits_total Total number of times a cluster health scrape was served from cache.
+		# TYPE elasticsearch_cluster_health_cache_hits_total counter
+		elasticsearch_cluster_health_cache_hits_total{url="` + u.String() + `"} 2
+		# HELP elasticsearch_cluster_health_cache_misses_total Total number of times a cluster health scrape required an upstream fetch.
+		# TYPE elasticsearch_cluster_health_cache_misses_total counter
+		elasticsearch_cluster_health_cache_misses_total{url="` + u.String() + `"} 1
+	`
+	metricNames := []string{
+		"elasticsearch_cluster_health_cache_hits_total",
+		"elasticsearch_cluster_health_cache_misses_total",
+	}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), metricNames...); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected a single upstream request across cached scrapes, got %d", got)
+	}
+}
+
+func TestClusterHealthCacheDisabledByDefault(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.ServeFile(w, r, "fixtures/cluster_health.json")
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %s", err)
+	}
+
+	c := NewClusterHealth(log.NewNopLogger(), http.DefaultClient, u)
+
+	ch := make(chan prometheus.Metric, 100)
+	c.Collect(ch)
+	c.Collect(ch)
+	close(ch)
+	for range ch {
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected cache to be disabled by default, got %d upstream requests, want 2", got)
+	}
+}
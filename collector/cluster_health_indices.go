@@ -0,0 +1,371 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var defaultClusterHealthIndexLabels = []string{"cluster", "index"}
+
+type clusterHealthIndexMetric struct {
+	Type  prometheus.ValueType
+	Desc  *prometheus.Desc
+	Value func(indexHealth clusterHealthIndexResponse) float64
+}
+
+type clusterHealthIndexStatusMetric struct {
+	Type  prometheus.ValueType
+	Desc  *prometheus.Desc
+	Value func(indexHealth clusterHealthIndexResponse, color string) float64
+}
+
+// ClusterHealthIndicesOption configures a ClusterHealthIndices collector.
+type ClusterHealthIndicesOption func(*ClusterHealthIndices)
+
+// WithIndicesLevel controls whether `/_cluster/health` is queried with
+// `?level=indices`, which is what adds the per-index breakdown this
+// collector reports on. It is enabled by default; disabling it falls back
+// to the cluster-wide response and the collector reports no index series,
+// unless WithShardsLevel is enabled, which takes precedence.
+func WithIndicesLevel(enabled bool) ClusterHealthIndicesOption {
+	return func(c *ClusterHealthIndices) {
+		c.indicesLevel = enabled
+	}
+}
+
+// WithShardsLevel additionally requests `?level=shards` instead of
+// `?level=indices`, which adds a per-shard breakdown reported as
+// cluster_health_indices_shard_state (one series per shard number, primary
+// flag and state, counting the copies in that state).
+// The response payload is considerably larger on big clusters, so it is
+// opt-in and disabled by default.
+func WithShardsLevel(enabled bool) ClusterHealthIndicesOption {
+	return func(c *ClusterHealthIndices) {
+		c.shardsLevel = enabled
+	}
+}
+
+// ClusterHealthIndices collects per-index shard health from
+// `/_cluster/health?level=indices`, complementing the cluster-wide totals
+// reported by ClusterHealth with a breakdown of which index is actually
+// red or yellow.
+type ClusterHealthIndices struct {
+	logger log.Logger
+	client *http.Client
+	url    *url.URL
+
+	indicesLevel bool
+	shardsLevel  bool
+
+	metrics        []*clusterHealthIndexMetric
+	statusMetric   *clusterHealthIndexStatusMetric
+	shardStateDesc *prometheus.Desc
+
+	totalScrapesMetric        *prometheus.CounterVec
+	lastScrapeErrorMetric     prometheus.Gauge
+	lastScrapeTimestampMetric prometheus.Gauge
+	scrapeDurationMetric      *prometheus.HistogramVec
+	scrapeDurationSummary     *prometheus.SummaryVec
+}
+
+// NewClusterHealthIndices defines a ClusterHealthIndices collector.
+func NewClusterHealthIndices(logger log.Logger, client *http.Client, url *url.URL, opts ...ClusterHealthIndicesOption) *ClusterHealthIndices {
+	subsystem := "cluster_health_indices"
+
+	c := &ClusterHealthIndices{
+		logger: logger,
+		client: client,
+		url:    url,
+
+		indicesLevel: true,
+
+		metrics: []*clusterHealthIndexMetric{
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "active_primary_shards"),
+					"The number of primary shards in this index.",
+					defaultClusterHealthIndexLabels, nil,
+				),
+				Value: func(indexHealth clusterHealthIndexResponse) float64 {
+					return float64(indexHealth.ActivePrimaryShards)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "active_shards"),
+					"Aggregate total of all shards for this index, including replicas.",
+					defaultClusterHealthIndexLabels, nil,
+				),
+				Value: func(indexHealth clusterHealthIndexResponse) float64 {
+					return float64(indexHealth.ActiveShards)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "initializing_shards"),
+					"Count of shards for this index that are being freshly created.",
+					defaultClusterHealthIndexLabels, nil,
+				),
+				Value: func(indexHealth clusterHealthIndexResponse) float64 {
+					return float64(indexHealth.InitializingShards)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "relocating_shards"),
+					"The number of shards for this index that are currently moving from one node to another node.",
+					defaultClusterHealthIndexLabels, nil,
+				),
+				Value: func(indexHealth clusterHealthIndexResponse) float64 {
+					return float64(indexHealth.RelocatingShards)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "unassigned_shards"),
+					"The number of shards for this index that exist in the cluster state, but cannot be found in the cluster itself.",
+					defaultClusterHealthIndexLabels, nil,
+				),
+				Value: func(indexHealth clusterHealthIndexResponse) float64 {
+					return float64(indexHealth.UnassignedShards)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "number_of_replicas"),
+					"Number of replicas configured for this index.",
+					defaultClusterHealthIndexLabels, nil,
+				),
+				Value: func(indexHealth clusterHealthIndexResponse) float64 {
+					return float64(indexHealth.NumberOfReplicas)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "number_of_shards"),
+					"Number of primary shards configured for this index.",
+					defaultClusterHealthIndexLabels, nil,
+				),
+				Value: func(indexHealth clusterHealthIndexResponse) float64 {
+					return float64(indexHealth.NumberOfShards)
+				},
+			},
+		},
+		statusMetric: &clusterHealthIndexStatusMetric{
+			Type: prometheus.GaugeValue,
+			Desc: prometheus.NewDesc(
+				prometheus.BuildFQName(namespace, subsystem, "status"),
+				"Whether all primary and replica shards of this index are allocated.",
+				[]string{"cluster", "index", "color"}, nil,
+			),
+			Value: func(indexHealth clusterHealthIndexResponse, color string) float64 {
+				if indexHealth.Status == color {
+					return 1
+				}
+				return 0
+			},
+		},
+		shardStateDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "shard_state"),
+			"Number of copies of a shard of this index in the given state. Only populated when WithShardsLevel is enabled.",
+			[]string{"cluster", "index", "shard", "primary", "state"}, nil,
+		),
+		totalScrapesMetric: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "scrapes_total",
+				Help:      "Total number of times ElasticSearch per-index cluster health was scraped for metrics, by outcome.",
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+			[]string{"outcome"},
+		),
+		lastScrapeErrorMetric: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "last_scrape_error",
+				Help:      "Whether the last scrape of metrics from ElasticSearch per-index cluster health resulted in an error (1 for error, 0 for success).",
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+		),
+		lastScrapeTimestampMetric: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "last_scrape_timestamp",
+				Help:      "Number of seconds since 1970 since last scrape from ElasticSearch per-index cluster health.",
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+		),
+		scrapeDurationMetric: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "scrape_duration_seconds",
+				Help:      "Duration of a scrape of ElasticSearch per-index cluster health, by outcome.",
+				Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+			[]string{"outcome"},
+		),
+		scrapeDurationSummary: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Namespace:  namespace,
+				Subsystem:  subsystem,
+				Name:       "scrape_duration_seconds_summary",
+				Help:       "Duration of a scrape of ElasticSearch per-index cluster health, by outcome, as quantiles.",
+				Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+			[]string{"outcome"},
+		),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *ClusterHealthIndices) Describe(ch chan<- *prometheus.Desc) {
+	for _, metric := range c.metrics {
+		ch <- metric.Desc
+	}
+	ch <- c.statusMetric.Desc
+	ch <- c.shardStateDesc
+
+	c.totalScrapesMetric.Describe(ch)
+	ch <- c.lastScrapeErrorMetric.Desc()
+	ch <- c.lastScrapeTimestampMetric.Desc()
+	c.scrapeDurationMetric.Describe(ch)
+	c.scrapeDurationSummary.Describe(ch)
+}
+
+func (c *ClusterHealthIndices) fetchAndDecodeClusterHealthIndices() (clusterHealthResponse, error) {
+	var chr clusterHealthResponse
+
+	u := *c.url
+	u.Path = "/_cluster/health"
+	q := u.Query()
+	switch {
+	case c.shardsLevel:
+		q.Set("level", "shards")
+	case c.indicesLevel:
+		q.Set("level", "indices")
+	}
+	u.RawQuery = q.Encode()
+
+	res, err := c.client.Get(u.String())
+	if err != nil {
+		return chr, fmt.Errorf("failed to get cluster health from %s: %s", u.String(), err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return chr, fmt.Errorf("HTTP Request failed with code %d", res.StatusCode)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&chr); err != nil {
+		return chr, err
+	}
+
+	return chr, nil
+}
+
+func (c *ClusterHealthIndices) Collect(ch chan<- prometheus.Metric) {
+	begun := time.Now()
+	scrapeError := 0
+
+	clusterHealth, err := c.fetchAndDecodeClusterHealthIndices()
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "failed to fetch and decode per-index cluster health",
+			"err", err,
+		)
+		scrapeError = 1
+	}
+
+	for indexName, indexHealth := range clusterHealth.Indices {
+		for _, metric := range c.metrics {
+			ch <- prometheus.MustNewConstMetric(
+				metric.Desc,
+				metric.Type,
+				metric.Value(indexHealth),
+				clusterHealth.ClusterName, indexName,
+			)
+		}
+
+		for _, color := range colors {
+			ch <- prometheus.MustNewConstMetric(
+				c.statusMetric.Desc,
+				c.statusMetric.Type,
+				c.statusMetric.Value(indexHealth, color),
+				clusterHealth.ClusterName, indexName, color,
+			)
+		}
+
+		shardCopies := map[[3]string]int{}
+		for shardID, copies := range indexHealth.Shards {
+			for _, shard := range copies {
+				primary := "false"
+				if shard.Primary {
+					primary = "true"
+				}
+				shardCopies[[3]string{shardID, primary, shard.State}]++
+			}
+		}
+		for key, count := range shardCopies {
+			ch <- prometheus.MustNewConstMetric(
+				c.shardStateDesc,
+				prometheus.GaugeValue,
+				float64(count),
+				clusterHealth.ClusterName, indexName, key[0], key[1], key[2],
+			)
+		}
+	}
+
+	outcome := "success"
+	if scrapeError == 1 {
+		outcome = "error"
+	}
+	c.totalScrapesMetric.WithLabelValues(outcome).Inc()
+	c.totalScrapesMetric.Collect(ch)
+
+	c.lastScrapeErrorMetric.Set(float64(scrapeError))
+	c.lastScrapeErrorMetric.Collect(ch)
+
+	c.lastScrapeTimestampMetric.Set(float64(time.Now().Unix()))
+	c.lastScrapeTimestampMetric.Collect(ch)
+
+	duration := time.Since(begun).Seconds()
+	c.scrapeDurationMetric.WithLabelValues(outcome).Observe(duration)
+	c.scrapeDurationMetric.Collect(ch)
+	c.scrapeDurationSummary.WithLabelValues(outcome).Observe(duration)
+	c.scrapeDurationSummary.Collect(ch)
+}
@@ -0,0 +1,151 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestClusterHealthIndices(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{
+			name: "6.x",
+			file: "fixtures/cluster_health_indices_6.json",
+			want: `
+				# HELP elasticsearch_cluster_health_indices_active_primary_shards The number of primary shards in this index.
+				# TYPE elasticsearch_cluster_health_indices_active_primary_shards gauge
+				elasticsearch_cluster_health_indices_active_primary_shards{cluster="elasticsearch",index="logs-2019.01"} 5
+				elasticsearch_cluster_health_indices_active_primary_shards{cluster="elasticsearch",index="twitter"} 5
+			`,
+		},
+		{
+			name: "7.x",
+			file: "fixtures/cluster_health_indices_7.json",
+			want: `
+				# HELP elasticsearch_cluster_health_indices_active_primary_shards The number of primary shards in this index.
+				# TYPE elasticsearch_cluster_health_indices_active_primary_shards gauge
+				elasticsearch_cluster_health_indices_active_primary_shards{cluster="docker-cluster",index="metrics-2021.04"} 6
+				elasticsearch_cluster_health_indices_active_primary_shards{cluster="docker-cluster",index="orders"} 6
+			`,
+		},
+		{
+			name: "8.x",
+			file: "fixtures/cluster_health_indices_8.json",
+			want: `
+				# HELP elasticsearch_cluster_health_indices_active_primary_shards The number of primary shards in this index.
+				# TYPE elasticsearch_cluster_health_indices_active_primary_shards gauge
+				elasticsearch_cluster_health_indices_active_primary_shards{cluster="es8-cluster",index="events-2023.09"} 3
+			`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.ServeFile(w, r, tt.file)
+			}))
+			defer ts.Close()
+
+			u, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("failed to parse test server URL: %s", err)
+			}
+
+			c := NewClusterHealthIndices(log.NewNopLogger(), http.DefaultClient, u)
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.want), "elasticsearch_cluster_health_indices_active_primary_shards"); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
+
+func TestClusterHealthIndicesLevelOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []ClusterHealthIndicesOption
+		wantLevel string
+		wantNoOpt bool
+	}{
+		{
+			name:      "default requests level=indices",
+			wantLevel: "indices",
+		},
+		{
+			name:      "WithIndicesLevel(false) omits the level param",
+			opts:      []ClusterHealthIndicesOption{WithIndicesLevel(false)},
+			wantNoOpt: true,
+		},
+		{
+			name:      "WithShardsLevel(true) requests level=shards",
+			opts:      []ClusterHealthIndicesOption{WithShardsLevel(true)},
+			wantLevel: "shards",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotQuery url.Values
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotQuery = r.URL.Query()
+				http.ServeFile(w, r, "fixtures/cluster_health_indices_6.json")
+			}))
+			defer ts.Close()
+
+			u, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("failed to parse test server URL: %s", err)
+			}
+
+			c := NewClusterHealthIndices(log.NewNopLogger(), http.DefaultClient, u, tt.opts...)
+			ch := make(chan prometheus.Metric, 100)
+			c.Collect(ch)
+			close(ch)
+			for range ch {
+			}
+
+			if tt.wantNoOpt {
+				if got := gotQuery.Get("level"); got != "" {
+					t.Errorf("expected no level param, got %q", got)
+				}
+				return
+			}
+			if got := gotQuery.Get("level"); got != tt.wantLevel {
+				t.Errorf("expected level=%s, got %q", tt.wantLevel, got)
+			}
+		})
+	}
+}
+
+func TestClusterHealthIndicesShardState(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "fixtures/cluster_health_indices_shards.json")
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %s", err)
+	}
+
+	c := NewClusterHealthIndices(log.NewNopLogger(), http.DefaultClient, u, WithShardsLevel(true))
+
+	want := `
+		# HELP elasticsearch_cluster_health_indices_shard_state Number of copies of a shard of this index in the given state. Only populated when WithShardsLevel is enabled.
+		# TYPE elasticsearch_cluster_health_indices_shard_state gauge
+		elasticsearch_cluster_health_indices_shard_state{cluster="elasticsearch",index="twitter",primary="false",shard="0",state="STARTED"} 1
+		elasticsearch_cluster_health_indices_shard_state{cluster="elasticsearch",index="twitter",primary="true",shard="0",state="STARTED"} 1
+	`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "elasticsearch_cluster_health_indices_shard_state"); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestClusterHealthMasterOnly(t *testing.T) {
+	tests := []struct {
+		name         string
+		masterFile   string
+		wantIsMaster string
+	}{
+		{
+			name:         "scraped node is master",
+			masterFile:   "fixtures/cat_master_self.json",
+			wantIsMaster: "1",
+		},
+		{
+			name:         "scraped node is not master",
+			masterFile:   "fixtures/cat_master_other.json",
+			wantIsMaster: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/_nodes/_local":
+					http.ServeFile(w, r, "fixtures/nodes_local.json")
+				case "/_cat/master":
+					http.ServeFile(w, r, tt.masterFile)
+				default:
+					http.ServeFile(w, r, "fixtures/cluster_health.json")
+				}
+			}))
+			defer ts.Close()
+
+			u, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("failed to parse test server URL: %s", err)
+			}
+
+			c := NewClusterHealth(log.NewNopLogger(), http.DefaultClient, u, WithMasterOnly(true))
+
+			want := `
+				# HELP elasticsearch_cluster_health_is_master Whether the scraped node is the elected master node (1) or not (0). Only populated when master-only mode is enabled.
+				# TYPE elasticsearch_cluster_health_is_master gauge
+				elasticsearch_cluster_health_is_master{cluster="elasticsearch",node="node-1"} ` + tt.wantIsMaster + `
+			`
+			if tt.wantIsMaster == "1" {
+				want += `
+					# HELP elasticsearch_cluster_health_active_primary_shards Tthe number of primary shards in your cluster. This is an aggregate total across all indices.
+					# TYPE elasticsearch_cluster_health_active_primary_shards gauge
+					elasticsearch_cluster_health_active_primary_shards{cluster="elasticsearch"} 10
+				`
+			}
+			metricNames := []string{
+				"elasticsearch_cluster_health_is_master",
+				"elasticsearch_cluster_health_active_primary_shards",
+			}
+			if err := testutil.CollectAndCompare(c, strings.NewReader(want), metricNames...); err != nil {
+				t.Errorf("unexpected collecting result:\n%s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestClusterHealth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "fixtures/cluster_health.json")
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %s", err)
+	}
+
+	c := NewClusterHealth(log.NewNopLogger(), http.DefaultClient, u)
+
+	want := `
+		# HELP elasticsearch_cluster_health_active_shards_percent The ratio of active shards in the cluster expressed as a percentage.
+		# TYPE elasticsearch_cluster_health_active_shards_percent gauge
+		elasticsearch_cluster_health_active_shards_percent{cluster="elasticsearch"} 66.6666666
+		# HELP elasticsearch_cluster_health_task_max_waiting_in_queue_seconds Tasks max time waiting in queue, in seconds.
+		# TYPE elasticsearch_cluster_health_task_max_waiting_in_queue_seconds gauge
+		elasticsearch_cluster_health_task_max_waiting_in_queue_seconds{cluster="elasticsearch"} 1.5
+	`
+	metricNames := []string{
+		"elasticsearch_cluster_health_active_shards_percent",
+		"elasticsearch_cluster_health_task_max_waiting_in_queue_seconds",
+	}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), metricNames...); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestClusterHealthScrapesTotalByOutcome(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %s", err)
+	}
+
+	c := NewClusterHealth(log.NewNopLogger(), http.DefaultClient, u)
+
+	ch := make(chan prometheus.Metric, 100)
+	c.Collect(ch)
+	close(ch)
+
+	got := testutil.ToFloat64(c.totalScrapesMetric.WithLabelValues("error"))
+	if got != 1 {
+		t.Errorf("expected scrapes_total{outcome=\"error\"} to be 1, got %v", got)
+	}
+}
@@ -0,0 +1,400 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var defaultClusterStatsLabels = []string{"cluster"}
+
+type clusterStatsResponse struct {
+	ClusterName string `json:"cluster_name"`
+	Indices     struct {
+		Count  int `json:"count"`
+		Shards struct {
+			Total int `json:"total"`
+		} `json:"shards"`
+		Docs struct {
+			Count int `json:"count"`
+		} `json:"docs"`
+		Store struct {
+			SizeInBytes int64 `json:"size_in_bytes"`
+		} `json:"store"`
+		FieldData struct {
+			MemorySizeInBytes int64 `json:"memory_size_in_bytes"`
+		} `json:"fielddata"`
+		QueryCache struct {
+			MemorySizeInBytes int64 `json:"memory_size_in_bytes"`
+		} `json:"query_cache"`
+		Segments struct {
+			Count int `json:"count"`
+		} `json:"segments"`
+	} `json:"indices"`
+	Nodes struct {
+		Count struct {
+			Master           int `json:"master"`
+			Data             int `json:"data"`
+			Ingest           int `json:"ingest"`
+			CoordinatingOnly int `json:"coordinating_only"`
+		} `json:"count"`
+		JVM struct {
+			Mem struct {
+				HeapUsedInBytes int64 `json:"heap_used_in_bytes"`
+			} `json:"mem"`
+		} `json:"jvm"`
+		OS struct {
+			Mem struct {
+				TotalInBytes int64 `json:"total_in_bytes"`
+			} `json:"mem"`
+		} `json:"os"`
+		FS struct {
+			TotalInBytes     int64 `json:"total_in_bytes"`
+			AvailableInBytes int64 `json:"available_in_bytes"`
+		} `json:"fs"`
+	} `json:"nodes"`
+}
+
+type clusterStatsMetric struct {
+	Type  prometheus.ValueType
+	Desc  *prometheus.Desc
+	Value func(clusterStats clusterStatsResponse) float64
+}
+
+// ClusterStats collects capacity-planning metrics from `/_cluster/stats`,
+// complementing the shard-focused metrics ClusterHealth reports with JVM,
+// OS, and fielddata aggregates across the cluster.
+type ClusterStats struct {
+	logger log.Logger
+	client *http.Client
+	url    *url.URL
+
+	metrics []*clusterStatsMetric
+
+	totalScrapesMetric        *prometheus.CounterVec
+	lastScrapeErrorMetric     prometheus.Gauge
+	lastScrapeTimestampMetric prometheus.Gauge
+	scrapeDurationMetric      *prometheus.HistogramVec
+	scrapeDurationSummary     *prometheus.SummaryVec
+}
+
+// NewClusterStats defines a ClusterStats collector.
+func NewClusterStats(logger log.Logger, client *http.Client, url *url.URL) *ClusterStats {
+	subsystem := "cluster_stats"
+
+	return &ClusterStats{
+		logger: logger,
+		client: client,
+		url:    url,
+
+		metrics: []*clusterStatsMetric{
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "indices_count"),
+					"Number of indices in the cluster.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Indices.Count)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "indices_shards_total"),
+					"Total number of shards across all indices in the cluster.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Indices.Shards.Total)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "indices_docs_count"),
+					"Total number of documents across all indices in the cluster.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Indices.Docs.Count)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "indices_store_size_bytes"),
+					"Total size of all indices in the cluster, in bytes.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Indices.Store.SizeInBytes)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "indices_fielddata_memory_size_bytes"),
+					"Memory used by fielddata across all indices in the cluster, in bytes.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Indices.FieldData.MemorySizeInBytes)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "indices_query_cache_memory_size_bytes"),
+					"Memory used by the query cache across all indices in the cluster, in bytes.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Indices.QueryCache.MemorySizeInBytes)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "indices_segments_count"),
+					"Total number of segments across all indices in the cluster.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Indices.Segments.Count)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "nodes_count_master"),
+					"Number of master-eligible nodes in the cluster.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Nodes.Count.Master)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "nodes_count_data"),
+					"Number of data nodes in the cluster.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Nodes.Count.Data)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "nodes_count_ingest"),
+					"Number of ingest nodes in the cluster.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Nodes.Count.Ingest)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "nodes_count_coordinating_only"),
+					"Number of coordinating-only nodes in the cluster.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Nodes.Count.CoordinatingOnly)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "nodes_jvm_heap_used_bytes"),
+					"JVM heap used across all nodes in the cluster, in bytes.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Nodes.JVM.Mem.HeapUsedInBytes)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "nodes_os_mem_total_bytes"),
+					"Total physical memory across all nodes in the cluster, in bytes.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Nodes.OS.Mem.TotalInBytes)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "nodes_fs_total_bytes"),
+					"Total disk space across all nodes in the cluster, in bytes.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Nodes.FS.TotalInBytes)
+				},
+			},
+			{
+				Type: prometheus.GaugeValue,
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(namespace, subsystem, "nodes_fs_available_bytes"),
+					"Available disk space across all nodes in the cluster, in bytes.",
+					defaultClusterStatsLabels, nil,
+				),
+				Value: func(clusterStats clusterStatsResponse) float64 {
+					return float64(clusterStats.Nodes.FS.AvailableInBytes)
+				},
+			},
+		},
+		totalScrapesMetric: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "scrapes_total",
+				Help:      "Total number of times ElasticSearch cluster stats was scraped for metrics, by outcome.",
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+			[]string{"outcome"},
+		),
+		lastScrapeErrorMetric: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "last_scrape_error",
+				Help:      "Whether the last scrape of metrics from ElasticSearch cluster stats resulted in an error (1 for error, 0 for success).",
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+		),
+		lastScrapeTimestampMetric: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "last_scrape_timestamp",
+				Help:      "Number of seconds since 1970 since last scrape from ElasticSearch cluster stats.",
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+		),
+		scrapeDurationMetric: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "scrape_duration_seconds",
+				Help:      "Duration of a scrape of ElasticSearch cluster stats, by outcome.",
+				Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+			[]string{"outcome"},
+		),
+		scrapeDurationSummary: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Namespace:  namespace,
+				Subsystem:  subsystem,
+				Name:       "scrape_duration_seconds_summary",
+				Help:       "Duration of a scrape of ElasticSearch cluster stats, by outcome, as quantiles.",
+				Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+				ConstLabels: prometheus.Labels{
+					"url": url.String(),
+				},
+			},
+			[]string{"outcome"},
+		),
+	}
+}
+
+func (c *ClusterStats) Describe(ch chan<- *prometheus.Desc) {
+	for _, metric := range c.metrics {
+		ch <- metric.Desc
+	}
+
+	c.totalScrapesMetric.Describe(ch)
+	ch <- c.lastScrapeErrorMetric.Desc()
+	ch <- c.lastScrapeTimestampMetric.Desc()
+	c.scrapeDurationMetric.Describe(ch)
+	c.scrapeDurationSummary.Describe(ch)
+}
+
+func (c *ClusterStats) fetchAndDecodeClusterStats() (clusterStatsResponse, error) {
+	var csr clusterStatsResponse
+
+	u := *c.url
+	u.Path = "/_cluster/stats"
+	res, err := c.client.Get(u.String())
+	if err != nil {
+		return csr, fmt.Errorf("failed to get cluster stats from %s: %s", u.String(), err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return csr, fmt.Errorf("HTTP Request failed with code %d", res.StatusCode)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&csr); err != nil {
+		return csr, err
+	}
+
+	return csr, nil
+}
+
+func (c *ClusterStats) Collect(ch chan<- prometheus.Metric) {
+	begun := time.Now()
+	scrapeError := 0
+
+	clusterStats, err := c.fetchAndDecodeClusterStats()
+	if err != nil {
+		level.Warn(c.logger).Log(
+			"msg", "failed to fetch and decode cluster stats",
+			"err", err,
+		)
+		scrapeError = 1
+	}
+
+	for _, metric := range c.metrics {
+		ch <- prometheus.MustNewConstMetric(
+			metric.Desc,
+			metric.Type,
+			metric.Value(clusterStats),
+			clusterStats.ClusterName,
+		)
+	}
+
+	outcome := "success"
+	if scrapeError == 1 {
+		outcome = "error"
+	}
+	c.totalScrapesMetric.WithLabelValues(outcome).Inc()
+	c.totalScrapesMetric.Collect(ch)
+
+	c.lastScrapeErrorMetric.Set(float64(scrapeError))
+	c.lastScrapeErrorMetric.Collect(ch)
+
+	c.lastScrapeTimestampMetric.Set(float64(time.Now().Unix()))
+	c.lastScrapeTimestampMetric.Collect(ch)
+
+	duration := time.Since(begun).Seconds()
+	c.scrapeDurationMetric.WithLabelValues(outcome).Observe(duration)
+	c.scrapeDurationMetric.Collect(ch)
+	c.scrapeDurationSummary.WithLabelValues(outcome).Observe(duration)
+	c.scrapeDurationSummary.Collect(ch)
+}
@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestClusterStats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "fixtures/cluster_stats.json")
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %s", err)
+	}
+
+	c := NewClusterStats(log.NewNopLogger(), http.DefaultClient, u)
+
+	want := `
+		# HELP elasticsearch_cluster_stats_indices_count Number of indices in the cluster.
+		# TYPE elasticsearch_cluster_stats_indices_count gauge
+		elasticsearch_cluster_stats_indices_count{cluster="elasticsearch"} 12
+		# HELP elasticsearch_cluster_stats_indices_docs_count Total number of documents across all indices in the cluster.
+		# TYPE elasticsearch_cluster_stats_indices_docs_count gauge
+		elasticsearch_cluster_stats_indices_docs_count{cluster="elasticsearch"} 1e+06
+		# HELP elasticsearch_cluster_stats_nodes_count_data Number of data nodes in the cluster.
+		# TYPE elasticsearch_cluster_stats_nodes_count_data gauge
+		elasticsearch_cluster_stats_nodes_count_data{cluster="elasticsearch"} 2
+		# HELP elasticsearch_cluster_stats_nodes_jvm_heap_used_bytes JVM heap used across all nodes in the cluster, in bytes.
+		# TYPE elasticsearch_cluster_stats_nodes_jvm_heap_used_bytes gauge
+		elasticsearch_cluster_stats_nodes_jvm_heap_used_bytes{cluster="elasticsearch"} 1.073741824e+09
+		# HELP elasticsearch_cluster_stats_nodes_fs_available_bytes Available disk space across all nodes in the cluster, in bytes.
+		# TYPE elasticsearch_cluster_stats_nodes_fs_available_bytes gauge
+		elasticsearch_cluster_stats_nodes_fs_available_bytes{cluster="elasticsearch"} 5.49755813888e+11
+	`
+	metricNames := []string{
+		"elasticsearch_cluster_stats_indices_count",
+		"elasticsearch_cluster_stats_indices_docs_count",
+		"elasticsearch_cluster_stats_nodes_count_data",
+		"elasticsearch_cluster_stats_nodes_jvm_heap_used_bytes",
+		"elasticsearch_cluster_stats_nodes_fs_available_bytes",
+	}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), metricNames...); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNewHTTPClientAuthorizationHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ClientConfig
+		want string
+	}{
+		{
+			name: "basic auth",
+			cfg:  ClientConfig{Username: "elastic", Password: "changeme"},
+			want: "Basic ZWxhc3RpYzpjaGFuZ2VtZQ==",
+		},
+		{
+			name: "api key",
+			cfg:  ClientConfig{APIKey: "VnVhQ2ZHY0JDZGJrUW0="},
+			want: "ApiKey VnVhQ2ZHY0JDZGJrUW0=",
+		},
+		{
+			name: "bearer token",
+			cfg:  ClientConfig{BearerToken: "some-token"},
+			want: "Bearer some-token",
+		},
+		{
+			name: "no credentials",
+			cfg:  ClientConfig{},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("Authorization")
+			}))
+			defer ts.Close()
+
+			cfg := tt.cfg
+			cfg.URL = ts.URL
+			client, u, err := NewHTTPClient(cfg)
+			if err != nil {
+				t.Fatalf("NewHTTPClient returned error: %s", err)
+			}
+
+			if _, err := client.Get(u.String()); err != nil {
+				t.Fatalf("request failed: %s", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("expected Authorization header %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientAPIKeyPrecedence(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	}))
+	defer ts.Close()
+
+	client, u, err := NewHTTPClient(ClientConfig{
+		URL:         ts.URL,
+		Username:    "elastic",
+		Password:    "changeme",
+		APIKey:      "some-api-key",
+		BearerToken: "some-bearer-token",
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPClient returned error: %s", err)
+	}
+
+	if _, err := client.Get(u.String()); err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+
+	if want := "ApiKey some-api-key"; got != want {
+		t.Errorf("expected APIKey to take precedence, got Authorization header %q, want %q", got, want)
+	}
+}
+
+func TestNewHTTPClientInvalidURL(t *testing.T) {
+	if _, _, err := NewHTTPClient(ClientConfig{URL: "://bad-url"}); err == nil {
+		t.Error("expected an error for an invalid URL, got nil")
+	}
+}
+
+// TestNewHTTPClientFeedsNewClusterHealth pins down that NewHTTPClient's
+// return values are accepted by NewClusterHealth as-is, with no adapter
+// code required to wire authenticated transports into the collector.
+func TestNewHTTPClientFeedsNewClusterHealth(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		http.ServeFile(w, r, "fixtures/cluster_health.json")
+	}))
+	defer ts.Close()
+
+	client, u, err := NewHTTPClient(ClientConfig{URL: ts.URL, APIKey: "some-api-key"})
+	if err != nil {
+		t.Fatalf("NewHTTPClient returned error: %s", err)
+	}
+
+	c := NewClusterHealth(log.NewNopLogger(), client, u)
+	ch := make(chan prometheus.Metric, 100)
+	c.Collect(ch)
+	close(ch)
+	for range ch {
+	}
+
+	if want := "ApiKey some-api-key"; got != want {
+		t.Errorf("expected Authorization header %q, got %q", want, got)
+	}
+}